@@ -0,0 +1,308 @@
+//
+//  Author: Hari Sekhon
+//  Date: 2024-10-06 11:52:07 +0100 (Sun, 06 Oct 2024)
+//
+//  vim:ts=4:sts=4:sw=4:et
+//
+//  https///github.com/HariSekhon/GitHub-Commit-Times-Graph
+//
+//  License: see accompanying Hari Sekhon LICENSE file
+//
+//  If you're using my code you're welcome to connect with me on LinkedIn and optionally send me feedback to help steer this or other code I publish
+//
+//  https://www.linkedin.com/in/HariSekhon
+//
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v41/github"
+	"golang.org/x/oauth2"
+)
+
+// newGitHubHTTPClient builds the http.Client used to talk to GitHub. Requests flow through, from
+// outermost to innermost: the oauth2 bearer-token transport, an ETag-aware transport so repeated
+// requests for unchanged pages come back as free 304s, and a rate-limit-aware transport that
+// backs off when quota gets low or GitHub returns a secondary rate-limit response.
+func newGitHubHTTPClient(ctx context.Context, token string) *http.Client {
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	return &http.Client{
+		Transport: &oauth2.Transport{
+			Source: ts,
+			Base: &etagTransport{
+				transport: &rateLimitTransport{transport: http.DefaultTransport},
+				cache:     make(map[string]*cachedResponse),
+			},
+		},
+	}
+}
+
+// cacheEntry is what's persisted to disk per owner/repo. Heatmap is always the full
+// day-of-week x hour grid (independent of the --bucket the user asked for) since it's the
+// most granular shape the other bucket modes, other than month, can be derived from.
+type cacheEntry struct {
+	SHA       string     `json:"sha"`
+	FetchedAt time.Time  `json:"fetched_at"`
+	Heatmap   [7][24]int `json:"heatmap"`
+}
+
+// cacheDir returns ~/.cache/github-commit-times
+func cacheDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".cache", "github-commit-times"), nil
+}
+
+func cachePath(owner, repo string) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, owner, repo+".json.gz"), nil
+}
+
+// loadCacheEntry returns hit=false (no error) if there's no cache file yet for owner/repo
+func loadCacheEntry(owner, repo string) (entry cacheEntry, hit bool, err error) {
+	path, err := cachePath(owner, repo)
+	if err != nil {
+		return cacheEntry{}, false, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cacheEntry{}, false, nil
+		}
+		return cacheEntry{}, false, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return cacheEntry{}, false, err
+	}
+	defer gz.Close()
+
+	if err := json.NewDecoder(gz).Decode(&entry); err != nil {
+		return cacheEntry{}, false, err
+	}
+
+	return entry, true, nil
+}
+
+func saveCacheEntry(owner, repo string, entry cacheEntry) error {
+	path, err := cachePath(owner, repo)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	defer gz.Close()
+
+	return json.NewEncoder(gz).Encode(entry)
+}
+
+// cacheable reports whether a repo's commit histogram can be served from/merged into the disk
+// cache. The cache only ever stores an unfiltered day-of-week x hour grid taken in each commit
+// timestamp's own zone (no --tz conversion), so it can't correctly serve a username filter, a
+// --tz zone, --tz=commit, a date range, or the month bucket (which
+// the grid carries no information for) - those always do a full, uncached fetch.
+func cacheable(usernameFilter string, loc *time.Location, useCommitTZ bool, bucket string, from, to time.Time) bool {
+	return usernameFilter == "" && loc == nil && !useCommitTZ && bucket != BucketMonth && from.IsZero() && to.IsZero()
+}
+
+// reshapeHistogram derives an hour or weekday histogram from the cache's canonical
+// day-of-week-hour grid; day-of-week-hour is returned as-is.
+func reshapeHistogram(grid CommitHistogram, bucket string) CommitHistogram {
+	if bucket == BucketDayOfWeekHour {
+		return grid
+	}
+
+	hist := newCommitHistogram(bucket)
+	weekdayNames := bucketLabels(BucketWeekday)
+	for weekday := 0; weekday < 7; weekday++ {
+		for hour := 0; hour < 24; hour++ {
+			count := grid.Heatmap[weekday][hour]
+			if bucket == BucketWeekday {
+				hist.Counts[weekdayNames[weekday]] += count
+			} else { // BucketHour
+				hist.Counts[fmt.Sprintf("%02d", hour)] += count
+			}
+		}
+	}
+	return hist
+}
+
+// fetchRepoHistogram fetches and aggregates a single repo's commits, using the on-disk cache when
+// the request is cacheable and noCache is false: refresh skips reading the cached entry but still
+// overwrites it with a full fetch. On a cache hit without refresh it only fetches commits newer
+// than the cached FetchedAt and merges them into the cached grid.
+//
+// Caveat: GitHub's 'since' filters by commit author date, not by when the commit actually landed
+// on the branch being listed. A commit authored on a long-lived feature branch before FetchedAt
+// but merged afterwards has an author date before 'since' and is silently missed by every future
+// incremental fetch, permanently undercounting the cached histogram with no signal that it
+// happened. The cache can't correct for this on its own (it only stores aggregate counts, not
+// per-commit SHAs, so it can't re-derive which window needs re-scanning) - run periodically with
+// --refresh to pick these up; see the --refresh note in showUsage.
+func fetchRepoHistogram(client *github.Client, owner, repo, usernameFilter string, loc *time.Location, useCommitTZ bool, bucket string, from, to time.Time, noCache, refresh bool) (CommitHistogram, error) {
+	if noCache || !cacheable(usernameFilter, loc, useCommitTZ, bucket, from, to) {
+		commits, err := fetchCommits(client, owner, repo)
+		if err != nil {
+			return CommitHistogram{}, err
+		}
+		return processCommits(commits, usernameFilter, loc, useCommitTZ, bucket, from, to), nil
+	}
+
+	entry, hit, err := loadCacheEntry(owner, repo)
+	if err != nil {
+		log.Printf("Warning: ignoring unreadable cache for %s/%s: %v", owner, repo, err)
+		hit = false
+	}
+
+	opt := &github.CommitsListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	if hit && !refresh {
+		opt.Since = entry.FetchedAt
+	}
+
+	var newCommits []*github.RepositoryCommit
+	headSHA := entry.SHA
+	for page := 0; ; page++ {
+		commits, resp, err := client.Repositories.ListCommits(context.Background(), owner, repo, opt)
+		if err != nil {
+			return CommitHistogram{}, err
+		}
+		if page == 0 && len(commits) > 0 {
+			headSHA = commits[0].GetSHA()
+		}
+		newCommits = append(newCommits, commits...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	grid := newCommitHistogram(BucketDayOfWeekHour)
+	if hit && !refresh {
+		grid.Heatmap = sliceFromGrid(entry.Heatmap)
+	}
+	mergeHistogram(&grid, processCommits(newCommits, "", nil, false, BucketDayOfWeekHour, time.Time{}, time.Time{}))
+
+	newEntry := cacheEntry{SHA: headSHA, FetchedAt: time.Now(), Heatmap: gridFromSlice(grid.Heatmap)}
+	if err := saveCacheEntry(owner, repo, newEntry); err != nil {
+		log.Printf("Warning: failed to write cache for %s/%s: %v", owner, repo, err)
+	}
+
+	return reshapeHistogram(grid, bucket), nil
+}
+
+func sliceFromGrid(g [7][24]int) [][]int {
+	out := make([][]int, 7)
+	for i := range out {
+		out[i] = append([]int(nil), g[i][:]...)
+	}
+	return out
+}
+
+func gridFromSlice(s [][]int) [7][24]int {
+	var g [7][24]int
+	for i := 0; i < len(s) && i < 7; i++ {
+		copy(g[i][:], s[i])
+	}
+	return g
+}
+
+// cachedResponse is a recorded 200 response body kept so a later 304 can be served from memory
+type cachedResponse struct {
+	ETag   string
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// etagTransport wraps an http.RoundTripper, attaching If-None-Match from a prior response's
+// ETag and serving the cached body back out on a 304 so unchanged pages don't count against
+// the GitHub rate limit. This transport is shared with the GraphQL v4 client (see
+// newGitHubHTTPClient), whose requests are all POSTs to the same /graphql URL with a different
+// body per page, so caching is restricted to GET requests to avoid a later page being served an
+// earlier page's cached body back under a 304.
+type etagTransport struct {
+	transport http.RoundTripper
+	mu        sync.Mutex
+	cache     map[string]*cachedResponse
+}
+
+func (t *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.transport.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+
+	t.mu.Lock()
+	cached := t.cache[key]
+	t.mu.Unlock()
+
+	if cached != nil && cached.ETag != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		return &http.Response{
+			Status:     "200 OK (cached)",
+			StatusCode: http.StatusOK,
+			Header:     cached.Header.Clone(),
+			Body:       io.NopCloser(bytes.NewReader(cached.Body)),
+			Request:    req,
+		}, nil
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr == nil {
+			t.mu.Lock()
+			t.cache[key] = &cachedResponse{
+				ETag:   resp.Header.Get("ETag"),
+				Status: resp.StatusCode,
+				Header: resp.Header.Clone(),
+				Body:   body,
+			}
+			t.mu.Unlock()
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	return resp, nil
+}
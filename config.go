@@ -0,0 +1,141 @@
+//
+//  Author: Hari Sekhon
+//  Date: 2024-09-22 14:10:31 +0100 (Sun, 22 Sep 2024)
+//
+//  vim:ts=4:sts=4:sw=4:et
+//
+//  https///github.com/HariSekhon/GitHub-Commit-Times-Graph
+//
+//  License: see accompanying Hari Sekhon LICENSE file
+//
+//  If you're using my code you're welcome to connect with me on LinkedIn and optionally send me feedback to help steer this or other code I publish
+//
+//  https://www.linkedin.com/in/HariSekhon
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v41/github"
+	"gopkg.in/yaml.v3"
+)
+
+// Target describes one entry in a --config YAML file: either an owner (all of their public repos,
+// excluding forks unless include_forks is set)
+// or a specific owner/repo, with an optional per-target user filter and date range
+type Target struct {
+	Owner        string `yaml:"owner"`
+	Repo         string `yaml:"repo"`
+	User         string `yaml:"user"`
+	IncludeForks bool   `yaml:"include_forks"`
+	Since        string `yaml:"since"`
+	Until        string `yaml:"until"`
+}
+
+// Config is the top-level shape of a --config YAML file, letting repeated multi-target runs be
+// checked into a repo instead of re-typed on the command line each time
+type Config struct {
+	Targets  []Target `yaml:"targets"`
+	Output   string   `yaml:"output"`
+	Timezone string   `yaml:"timezone"`
+}
+
+// loadConfig reads and parses a --config YAML file
+func loadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// targetRepo is a resolved owner/repo pair to fetch commits from
+type targetRepo struct {
+	Owner string
+	Repo  string
+}
+
+// parseTargetDateRange parses a target's since/until (YYYY-MM-DD) into a [from, to] window,
+// making 'until' inclusive of the whole day like the --from/--to CLI flags do
+func parseTargetDateRange(target Target) (from, to time.Time, err error) {
+	if target.Since != "" {
+		from, err = time.Parse("2006-01-02", target.Since)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid 'since' %q: %w", target.Since, err)
+		}
+	}
+	if target.Until != "" {
+		parsed, parseErr := time.Parse("2006-01-02", target.Until)
+		if parseErr != nil {
+			return from, to, fmt.Errorf("invalid 'until' %q: %w", target.Until, parseErr)
+		}
+		to = parsed.Add(24*time.Hour - time.Nanosecond)
+	}
+	return from, to, nil
+}
+
+// runConfig dispatches every target in cfg through the same worker-pooled fetchReposConcurrently
+// pipeline as the CLI flow, merging all of their commits into a single histogram. When stacked is
+// true, each repo's own histogram is also returned (keyed by "owner/repo") for --stacked rendering.
+func runConfig(client *github.Client, cfg Config, bucket string, noCache, refresh bool, concurrency int, stacked bool) (CommitHistogram, map[string]CommitHistogram, error) {
+	var loc *time.Location
+	useCommitTZ := cfg.Timezone == "commit"
+	if !useCommitTZ && cfg.Timezone != "" {
+		var err error
+		loc, err = time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return CommitHistogram{}, nil, fmt.Errorf("invalid timezone %q: %w", cfg.Timezone, err)
+		}
+	}
+
+	combined := newCommitHistogram(bucket)
+	var perRepo map[string]CommitHistogram
+	if stacked {
+		perRepo = make(map[string]CommitHistogram)
+	}
+
+	for _, target := range cfg.Targets {
+		from, to, err := parseTargetDateRange(target)
+		if err != nil {
+			return CommitHistogram{}, nil, err
+		}
+
+		var repos []targetRepo
+		switch {
+		case target.Repo != "":
+			parts := strings.Split(target.Repo, "/")
+			if len(parts) != 2 {
+				return CommitHistogram{}, nil, fmt.Errorf("invalid repo format %q in config target (expected 'owner/repo')", target.Repo)
+			}
+			repos = append(repos, targetRepo{Owner: parts[0], Repo: parts[1]})
+		case target.Owner != "":
+			ownerRepos, err := fetchUserRepos(client, target.Owner, target.IncludeForks)
+			if err != nil {
+				return CommitHistogram{}, nil, fmt.Errorf("fetching repos for %s: %w", target.Owner, err)
+			}
+			for _, repo := range ownerRepos {
+				repos = append(repos, targetRepo{Owner: target.Owner, Repo: repo.GetName()})
+			}
+		default:
+			return CommitHistogram{}, nil, fmt.Errorf("config target must set either 'owner' or 'repo'")
+		}
+
+		if err := fetchReposConcurrently(context.Background(), client, repos, concurrency, target.User, loc, useCommitTZ, bucket, from, to, noCache, refresh, &combined, perRepo); err != nil {
+			return CommitHistogram{}, nil, err
+		}
+	}
+
+	return combined, perRepo, nil
+}
@@ -26,17 +26,106 @@ import (
 	"image/color"
 	"log"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v41/github"
-	"golang.org/x/oauth2"
+	"github.com/shurcooL/githubv4"
 	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/palette"
 	"gonum.org/v1/plot/plotter"
 	"gonum.org/v1/plot/vg"
-	"gonum.org/v1/plot/vg/vgsvg"
-	"gonum.org/v1/plot/vg/draw"
 )
 
+// A repository discovered via the GraphQL v4 API, e.g. one the user has contributed to
+// rather than one they own outright
+type V4Repo struct {
+	Owner      string
+	Repository string
+}
+
+// Supported --bucket aggregation modes
+const (
+	BucketHour          = "hour"
+	BucketWeekday       = "weekday"
+	BucketDayOfWeekHour = "day-of-week-hour"
+	BucketMonth         = "month"
+)
+
+// bucketLabels returns the ordered category labels for a bucket mode, used both to drive the
+// x-axis of the bar chart and to pre-seed the histogram so empty categories still show up
+func bucketLabels(bucket string) []string {
+	switch bucket {
+	case BucketWeekday:
+		return []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+	case BucketMonth:
+		return []string{
+			"January", "February", "March", "April", "May", "June",
+			"July", "August", "September", "October", "November", "December",
+		}
+	default: // BucketHour
+		labels := make([]string, 24)
+		for i := 0; i < 24; i++ {
+			labels[i] = fmt.Sprintf("%02d", i)
+		}
+		return labels
+	}
+}
+
+// CommitHistogram holds the aggregated commit counts for one bucket mode. Counts is used for
+// hour/weekday/month, Heatmap (7 rows of weekday x 24 cols of hour) is used for day-of-week-hour
+type CommitHistogram struct {
+	Bucket  string
+	Counts  map[string]int
+	Heatmap [][]int
+}
+
+// newCommitHistogram creates a zero-valued histogram for the given bucket, pre-seeded with every
+// category so merges and rendering don't need to special-case missing keys
+func newCommitHistogram(bucket string) CommitHistogram {
+	hist := CommitHistogram{Bucket: bucket}
+	if bucket == BucketDayOfWeekHour {
+		hist.Heatmap = make([][]int, 7)
+		for i := range hist.Heatmap {
+			hist.Heatmap[i] = make([]int, 24)
+		}
+	} else {
+		hist.Counts = make(map[string]int)
+		for _, label := range bucketLabels(bucket) {
+			hist.Counts[label] = 0
+		}
+	}
+	return hist
+}
+
+// mergeHistogram adds src's counts into dst, which must have been created with the same bucket
+func mergeHistogram(dst *CommitHistogram, src CommitHistogram) {
+	if dst.Bucket == BucketDayOfWeekHour {
+		for weekday := range dst.Heatmap {
+			for hour := range dst.Heatmap[weekday] {
+				dst.Heatmap[weekday][hour] += src.Heatmap[weekday][hour]
+			}
+		}
+		return
+	}
+	for label, count := range src.Counts {
+		dst.Counts[label] += count
+	}
+}
+
+// heatmapGrid adapts a 7x24 day-of-week x hour grid to gonum's plotter.GridXYZ interface
+type heatmapGrid struct {
+	data [][]int
+}
+
+func (g *heatmapGrid) Dims() (c, r int) { return 24, len(g.data) }
+func (g *heatmapGrid) X(c int) float64  { return float64(c) }
+func (g *heatmapGrid) Y(r int) float64  { return float64(r) }
+func (g *heatmapGrid) Z(c, r int) float64 {
+	return float64(g.data[r][c])
+}
+
 // Fetch all commits from a repository with pagination
 func fetchCommits(client *github.Client, owner, repo string) ([]*github.RepositoryCommit, error) {
 	var allCommits []*github.RepositoryCommit
@@ -59,8 +148,8 @@ func fetchCommits(client *github.Client, owner, repo string) ([]*github.Reposito
 	return allCommits, nil
 }
 
-// Fetch all public, non-fork repositories for a user with pagination
-func fetchUserRepos(client *github.Client, user string) ([]*github.Repository, error) {
+// Fetch all public repositories for a user with pagination, excluding forks unless includeForks is set
+func fetchUserRepos(client *github.Client, user string, includeForks bool) ([]*github.Repository, error) {
 	var allRepos []*github.Repository
 	opt := &github.RepositoryListOptions{Type: "public", ListOptions: github.ListOptions{PerPage: 100}}
 
@@ -71,7 +160,7 @@ func fetchUserRepos(client *github.Client, user string) ([]*github.Repository, e
 		}
 
 		for _, repo := range repos {
-			if !repo.GetFork() {
+			if includeForks || !repo.GetFork() {
 				allRepos = append(allRepos, repo)
 			}
 		}
@@ -85,9 +174,60 @@ func fetchUserRepos(client *github.Client, user string) ([]*github.Repository, e
 	return allRepos, nil
 }
 
-// Process commits and aggregate by hour
-func processCommits(commits []*github.RepositoryCommit, usernameFilter string) [24]int {
-	hourlyCommits := [24]int{}
+// Fetch repositories the user has contributed to (commits or PRs) but doesn't necessarily own,
+// via the GraphQL v4 API since the REST Repositories.List call only returns owned repos
+func fetchContributedRepos(ctx context.Context, v4client *githubv4.Client, username string) ([]V4Repo, error) {
+	var query struct {
+		User struct {
+			RepositoriesContributedTo struct {
+				Nodes []struct {
+					Name  githubv4.String
+					Owner struct {
+						Login githubv4.String
+					}
+				}
+				PageInfo struct {
+					EndCursor   githubv4.String
+					HasNextPage bool
+				}
+			} `graphql:"repositoriesContributedTo(contributionTypes: [COMMIT, PULL_REQUEST], first: 100, after: $cursor)"`
+		} `graphql:"user(login: $userLogin)"`
+	}
+
+	variables := map[string]interface{}{
+		"userLogin": githubv4.String(username),
+		"cursor":    (*githubv4.String)(nil),
+	}
+
+	var repos []V4Repo
+	for {
+		if err := v4client.Query(ctx, &query, variables); err != nil {
+			return nil, err
+		}
+
+		for _, node := range query.User.RepositoriesContributedTo.Nodes {
+			repos = append(repos, V4Repo{
+				Owner:      string(node.Owner.Login),
+				Repository: string(node.Name),
+			})
+		}
+
+		if !query.User.RepositoriesContributedTo.PageInfo.HasNextPage {
+			break
+		}
+		variables["cursor"] = githubv4.NewString(query.User.RepositoriesContributedTo.PageInfo.EndCursor)
+	}
+
+	return repos, nil
+}
+
+// Process commits and aggregate them into the histogram shape the bucket mode calls for.
+// If useCommitTZ is true, each commit is left in the committer's own offset (go-github preserves
+// the original fixed-zone Location when it unmarshals the ISO-8601 timestamp), otherwise loc is
+// applied via .In(loc) when non-nil, falling back to whatever zone the commit timestamp carries.
+// Commits outside the [from, to] window are dropped; a zero from/to leaves that side unbounded.
+func processCommits(commits []*github.RepositoryCommit, usernameFilter string, loc *time.Location, useCommitTZ bool, bucket string, from, to time.Time) CommitHistogram {
+	hist := newCommitHistogram(bucket)
 
 	for _, commit := range commits {
 		if commit.Commit == nil || commit.Commit.Committer == nil {
@@ -99,70 +239,185 @@ func processCommits(commits []*github.RepositoryCommit, usernameFilter string) [
 		}
 
 		commitTime := commit.Commit.Committer.GetDate()
-		hour := commitTime.Hour()
+		if !useCommitTZ && loc != nil {
+			commitTime = commitTime.In(loc)
+		}
 
-		hourlyCommits[hour]++
+		if !from.IsZero() && commitTime.Before(from) {
+			continue
+		}
+		if !to.IsZero() && commitTime.After(to) {
+			continue
+		}
+
+		switch bucket {
+		case BucketDayOfWeekHour:
+			hist.Heatmap[int(commitTime.Weekday())][commitTime.Hour()]++
+		case BucketWeekday:
+			hist.Counts[commitTime.Weekday().String()]++
+		case BucketMonth:
+			hist.Counts[commitTime.Month().String()]++
+		default: // BucketHour
+			hist.Counts[fmt.Sprintf("%02d", commitTime.Hour())]++
+		}
 	}
 
-	return hourlyCommits
+	return hist
 }
 
-// Generate a bar graph and save it as an SVG file
-func generateGraph(hourlyCommits [24]int, outputFile, usernameFilter, repoFilter string) error {
-	p := plot.New()
+// bucketTitles maps each bucket mode to the label used in the graph title and axis
+var bucketTitles = map[string]string{
+	BucketHour:          "Hour",
+	BucketWeekday:       "Weekday",
+	BucketDayOfWeekHour: "Day of Week x Hour",
+	BucketMonth:         "Month",
+}
 
-	// Set the graph title based on the filter type
-	if usernameFilter != "" {
-		p.Title.Text = fmt.Sprintf("GitHub Commits by Hour for %s", usernameFilter)
-	} else if repoFilter != "" {
-		p.Title.Text = fmt.Sprintf("GitHub Commits by Hour for Repos under %s", repoFilter)
-	} else {
-		p.Title.Text = "GitHub Commits by Hour"
+// graphTitle builds the title shared by generateGraph and generateStackedGraph
+func graphTitle(bucketTitle, usernameFilter, repoFilter, tzLabel string) string {
+	var title string
+	switch {
+	case usernameFilter != "":
+		title = fmt.Sprintf("GitHub Commits by %s for %s", bucketTitle, usernameFilter)
+	case repoFilter != "":
+		title = fmt.Sprintf("GitHub Commits by %s for Repos under %s", bucketTitle, repoFilter)
+	default:
+		title = fmt.Sprintf("GitHub Commits by %s", bucketTitle)
 	}
-	p.X.Label.Text = "Hour of Day"
-	p.Y.Label.Text = "Number of Commits"
-
-	// Create the bar data
-	values := make(plotter.Values, 24)
-	for i := 0; i < 24; i++ {
-		values[i] = float64(hourlyCommits[i])
+	if tzLabel != "" {
+		title += fmt.Sprintf(" (%s)", tzLabel)
 	}
+	return title
+}
 
-	barChart, err := plotter.NewBarChart(values, vg.Points(20))
-	if err != nil {
-		return err
+// Generate a graph of the commit histogram and render it via renderer. Bar charts are used for
+// hour/weekday/month buckets; day-of-week-hour renders as a 7x24 heatmap instead.
+func generateGraph(hist CommitHistogram, outputFile, usernameFilter, repoFilter, tzLabel string, renderer Renderer) error {
+	p := plot.New()
+
+	bucketTitle := bucketTitles[hist.Bucket]
+	if bucketTitle == "" {
+		bucketTitle = bucketTitles[BucketHour]
 	}
 
-	// Set the bar color to blue
-	barChart.Color = color.RGBA{R: 0, G: 0, B: 255, A: 255} // Blue color
+	p.Title.Text = graphTitle(bucketTitle, usernameFilter, repoFilter, tzLabel)
+
+	var data RenderData
+	if hist.Bucket == BucketDayOfWeekHour {
+		p.X.Label.Text = "Hour of Day"
+		p.Y.Label.Text = "Day of Week"
+
+		heatMap := plotter.NewHeatMap(&heatmapGrid{data: hist.Heatmap}, palette.Heat(192, 1))
+		p.Add(heatMap)
+
+		p.NominalX(bucketLabels(BucketHour)...)
+		p.NominalY(bucketLabels(BucketWeekday)...)
+	} else {
+		p.X.Label.Text = bucketTitle
+		p.Y.Label.Text = "Number of Commits"
+
+		// Create the bar data
+		labels := bucketLabels(hist.Bucket)
+		values := make(plotter.Values, len(labels))
+		for i, label := range labels {
+			values[i] = float64(hist.Counts[label])
+		}
+
+		barChart, err := plotter.NewBarChart(values, vg.Points(20))
+		if err != nil {
+			return err
+		}
+
+		// Set the bar color to blue
+		barChart.Color = color.RGBA{R: 0, G: 0, B: 255, A: 255} // Blue color
 
-	p.Add(barChart)
+		p.Add(barChart)
 
-	// Set the x-axis labels to represent hours (0-23)
-	p.NominalX("00", "01", "02", "03", "04", "05", "06", "07", "08", "09", "10", "11", "12", "13", "14", "15", "16", "17", "18", "19", "20", "21", "22", "23")
+		// Set the x-axis labels to represent the bucket categories
+		p.NominalX(labels...)
+
+		data = RenderData{Labels: labels, Values: []float64(values)}
+	}
 
-	// Create the SVG canvas with the correct width and height
 	width := vg.Inch * 10
 	height := vg.Inch * 4
-	canvas := vgsvg.New(width, height)
+	return renderer.Render(p, width, height, outputFile, data)
+}
 
-	// Draw the plot on the canvas
-	p.Draw(draw.New(canvas))
+// stackedPalette returns n distinct, repeating bar colors for generateStackedGraph
+func stackedPalette(n int) []color.Color {
+	base := []color.RGBA{
+		{R: 31, G: 119, B: 180, A: 255},
+		{R: 255, G: 127, B: 14, A: 255},
+		{R: 44, G: 160, B: 44, A: 255},
+		{R: 214, G: 39, B: 40, A: 255},
+		{R: 148, G: 103, B: 189, A: 255},
+		{R: 140, G: 86, B: 75, A: 255},
+	}
+	colors := make([]color.Color, n)
+	for i := range colors {
+		colors[i] = base[i%len(base)]
+	}
+	return colors
+}
 
-	// Save the SVG to the file
-	w, err := os.Create(outputFile)
-	if err != nil {
-		return err
+// generateStackedGraph renders one stacked bar chart per repo in perRepo, so the contribution of
+// each repo to each bucket category is visible rather than just the combined total. Not supported
+// for the day-of-week-hour bucket, which renders as a single heatmap with no per-bar composition.
+func generateStackedGraph(perRepo map[string]CommitHistogram, bucket, outputFile, usernameFilter, repoFilter, tzLabel string, renderer Renderer) error {
+	if bucket == BucketDayOfWeekHour {
+		return fmt.Errorf("--stacked is not supported with --bucket=day-of-week-hour")
 	}
-	defer w.Close()
 
-	_, err = canvas.WriteTo(w)
-	if err != nil {
-		return err
+	names := make([]string, 0, len(perRepo))
+	for name := range perRepo {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bucketTitle := bucketTitles[bucket]
+	if bucketTitle == "" {
+		bucketTitle = bucketTitles[BucketHour]
 	}
 
-	fmt.Printf("Graph saved to %s\n", outputFile)
-	return nil
+	p := plot.New()
+	p.Title.Text = graphTitle(bucketTitle, usernameFilter, repoFilter, tzLabel) + " (stacked by repo)"
+	p.X.Label.Text = bucketTitle
+	p.Y.Label.Text = "Number of Commits"
+
+	labels := bucketLabels(bucket)
+	colors := stackedPalette(len(names))
+	perRepoValues := make(map[string][]float64, len(names))
+
+	var prev *plotter.BarChart
+	for i, name := range names {
+		hist := perRepo[name]
+		values := make(plotter.Values, len(labels))
+		for j, label := range labels {
+			values[j] = float64(hist.Counts[label])
+		}
+
+		barChart, err := plotter.NewBarChart(values, vg.Points(20))
+		if err != nil {
+			return err
+		}
+		barChart.Color = colors[i]
+		if prev != nil {
+			barChart.StackOn(prev)
+		}
+
+		p.Add(barChart)
+		p.Legend.Add(name, barChart)
+		prev = barChart
+
+		perRepoValues[name] = []float64(values)
+	}
+
+	p.NominalX(labels...)
+
+	width := vg.Inch * 10
+	height := vg.Inch * 4
+	return renderer.Render(p, width, height, outputFile, RenderData{Labels: labels, PerRepo: perRepoValues})
 }
 
 // Show usage help
@@ -172,6 +427,21 @@ func showUsage() {
 	fmt.Println("  --user <username/email>    Filter commits by a specific username or email")
 	fmt.Println("  --repo <owner/repo>        Filter commits by specific repository (format: 'owner/repo')")
 	fmt.Println("  -o, --output <file>        Output file for the graph (default: graph.svg)")
+	fmt.Println("  --contributed              When given a bare username, also include repos they've contributed to (not just owned)")
+	fmt.Println("  --tz <zone>                Convert commit times to this IANA zone before aggregating by hour (e.g. Europe/Paris)")
+	fmt.Println("  --tz=commit                Keep each commit in the committer's own offset instead of converting")
+	fmt.Println("  --from <YYYY-MM-DD>        Only include commits on or after this date")
+	fmt.Println("  --to <YYYY-MM-DD>          Only include commits on or before this date")
+	fmt.Println("  --bucket <mode>            Aggregation bucket: hour (default), weekday, day-of-week-hour, month")
+	fmt.Println("  --config <config.yaml>     Run multiple targets defined in a YAML config file instead of the CLI args")
+	fmt.Println("  --no-cache                 Don't read or write the on-disk commit cache (~/.cache/github-commit-times)")
+	fmt.Println("  --refresh                  Ignore the cached commit history and re-fetch everything, overwriting the cache")
+	fmt.Println("                             (the incremental cache filters by commit author date, so commits authored")
+	fmt.Println("                             on a branch before a prior run and merged after it are permanently missed")
+	fmt.Println("                             unless you --refresh periodically)")
+	fmt.Println("  --concurrency <N>          Number of repos to fetch in parallel when given a bare owner (default: 8)")
+	fmt.Println("  --format <svg|png|html>    Output format, overriding the extension sniffed from --output (svg if not .png/.html)")
+	fmt.Println("  --stacked                  When aggregating multiple repos, render a stacked bar chart showing each repo's contribution")
 	fmt.Println("  -h, --help                 Show this help message")
 	fmt.Println("Repos:")
 	fmt.Println("  Provide repositories in the format 'owner/repo'.")
@@ -184,6 +454,17 @@ func main() {
 	flag.StringVar(&userFilter, "user", "", "Filter commits by a specific username or email")
 	flag.StringVar(&repoFilter, "repo", "", "Filter commits by specific repository (format: 'owner/repo')")
 	flag.StringVar(&outputFile, "output", "graph.svg", "Output file for the graph")
+	contributedFlag := flag.Bool("contributed", false, "When given a bare username, also include repos they've contributed to (not just owned)")
+	tzFlag := flag.String("tz", "", "Convert commit times to this IANA zone before aggregating by hour, or 'commit' to keep each commit's own offset")
+	fromFlag := flag.String("from", "", "Only include commits on or after this date (YYYY-MM-DD)")
+	toFlag := flag.String("to", "", "Only include commits on or before this date (YYYY-MM-DD)")
+	bucketFlag := flag.String("bucket", BucketHour, "Aggregation bucket: hour, weekday, day-of-week-hour, month")
+	configFlag := flag.String("config", "", "Run multiple targets defined in a YAML config file instead of the CLI args")
+	noCacheFlag := flag.Bool("no-cache", false, "Don't read or write the on-disk commit cache")
+	refreshFlag := flag.Bool("refresh", false, "Ignore the cached commit history and re-fetch everything")
+	concurrencyFlag := flag.Int("concurrency", 8, "Number of repos to fetch in parallel when given a bare owner")
+	formatFlag := flag.String("format", "", "Output format: svg, png or html (default: sniffed from --output's extension)")
+	stackedFlag := flag.Bool("stacked", false, "When aggregating multiple repos, render a stacked bar chart showing each repo's contribution")
 	helpFlag := flag.Bool("help", false, "Show help")
 	flag.BoolVar(helpFlag, "h", false, "Show help")
 	flag.Parse()
@@ -193,8 +474,8 @@ func main() {
 	}
 
 	repoArgs := flag.Args()
-	if len(repoArgs) == 0 && repoFilter == "" && userFilter == "" {
-		fmt.Println("Error: No repositories, username, or repo filter provided.")
+	if *configFlag == "" && len(repoArgs) == 0 && repoFilter == "" && userFilter == "" {
+		fmt.Println("Error: No repositories, username, repo filter, or config file provided.")
 		showUsage()
 	}
 
@@ -204,11 +485,80 @@ func main() {
 	}
 
 	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-	tc := oauth2.NewClient(ctx, ts)
+	tc := newGitHubHTTPClient(ctx, token)
 	client := github.NewClient(tc)
+	v4client := githubv4.NewClient(tc)
+
+	switch *bucketFlag {
+	case BucketHour, BucketWeekday, BucketDayOfWeekHour, BucketMonth:
+	default:
+		log.Fatalf("Invalid --bucket value %q (expected hour, weekday, day-of-week-hour or month)", *bucketFlag)
+	}
+
+	if *configFlag != "" {
+		cfg, err := loadConfig(*configFlag)
+		if err != nil {
+			log.Fatalf("Error loading config %s: %v", *configFlag, err)
+		}
+
+		hist, perRepo, err := runConfig(client, cfg, *bucketFlag, *noCacheFlag, *refreshFlag, *concurrencyFlag, *stackedFlag)
+		if err != nil {
+			log.Fatalf("Error running config %s: %v", *configFlag, err)
+		}
+
+		out := outputFile
+		if cfg.Output != "" {
+			out = cfg.Output
+		}
+
+		renderer, err := pickRenderer(out, *formatFlag)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+
+		if *stackedFlag {
+			err = generateStackedGraph(perRepo, *bucketFlag, out, "", "", cfg.Timezone, renderer)
+		} else {
+			err = generateGraph(hist, out, "", "", cfg.Timezone, renderer)
+		}
+		if err != nil {
+			log.Fatalf("Error generating graph: %v", err)
+		}
+		return
+	}
 
-	hourlyCommits := [24]int{}
+	var loc *time.Location
+	useCommitTZ := *tzFlag == "commit"
+	if !useCommitTZ && *tzFlag != "" {
+		var err error
+		loc, err = time.LoadLocation(*tzFlag)
+		if err != nil {
+			log.Fatalf("Invalid --tz value %q: %v", *tzFlag, err)
+		}
+	}
+
+	var from, to time.Time
+	if *fromFlag != "" {
+		var err error
+		from, err = time.Parse("2006-01-02", *fromFlag)
+		if err != nil {
+			log.Fatalf("Invalid --from value %q: %v", *fromFlag, err)
+		}
+	}
+	if *toFlag != "" {
+		parsed, err := time.Parse("2006-01-02", *toFlag)
+		if err != nil {
+			log.Fatalf("Invalid --to value %q: %v", *toFlag, err)
+		}
+		// make --to inclusive of the whole day
+		to = parsed.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	combined := newCommitHistogram(*bucketFlag)
+	var perRepo map[string]CommitHistogram
+	if *stackedFlag {
+		perRepo = make(map[string]CommitHistogram)
+	}
 
 	if repoFilter != "" {
 		parts := strings.Split(repoFilter, "/")
@@ -219,14 +569,13 @@ func main() {
 		repo := parts[1]
 
 		fmt.Printf("Fetching commits from %s/%s...\n", owner, repo)
-		commits, err := fetchCommits(client, owner, repo)
+		repoHist, err := fetchRepoHistogram(client, owner, repo, userFilter, loc, useCommitTZ, *bucketFlag, from, to, *noCacheFlag, *refreshFlag)
 		if err != nil {
 			log.Fatalf("Error fetching commits from %s/%s: %v", owner, repo, err)
 		}
-
-		repoHourlyCommits := processCommits(commits, userFilter)
-		for hour := 0; hour < 24; hour++ {
-			hourlyCommits[hour] += repoHourlyCommits[hour]
+		mergeHistogram(&combined, repoHist)
+		if perRepo != nil {
+			perRepo[owner+"/"+repo] = repoHist
 		}
 	} else {
 		for _, repoArg := range repoArgs {
@@ -239,40 +588,57 @@ func main() {
 				repo := parts[1]
 
 				fmt.Printf("Fetching commits from %s/%s...\n", owner, repo)
-				commits, err := fetchCommits(client, owner, repo)
+				repoHist, err := fetchRepoHistogram(client, owner, repo, userFilter, loc, useCommitTZ, *bucketFlag, from, to, *noCacheFlag, *refreshFlag)
 				if err != nil {
 					log.Fatalf("Error fetching commits from %s/%s: %v", owner, repo, err)
 				}
-
-				repoHourlyCommits := processCommits(commits, userFilter)
-				for hour := 0; hour < 24; hour++ {
-					hourlyCommits[hour] += repoHourlyCommits[hour]
+				mergeHistogram(&combined, repoHist)
+				if perRepo != nil {
+					perRepo[owner+"/"+repo] = repoHist
 				}
 			} else {
 				user := repoArg
 				fmt.Printf("Fetching public non-fork repos for user %s...\n", user)
-				repos, err := fetchUserRepos(client, user)
+				repos, err := fetchUserRepos(client, user, false)
 				if err != nil {
 					log.Fatalf("Error fetching repos for user %s: %v", user, err)
 				}
 
+				var targets []targetRepo
 				for _, repo := range repos {
-					fmt.Printf("Fetching commits from %s/%s...\n", user, repo.GetName())
-					commits, err := fetchCommits(client, user, repo.GetName())
+					targets = append(targets, targetRepo{Owner: user, Repo: repo.GetName()})
+				}
+
+				if *contributedFlag {
+					fmt.Printf("Fetching repos contributed to by %s...\n", user)
+					contributedRepos, err := fetchContributedRepos(ctx, v4client, user)
 					if err != nil {
-						log.Fatalf("Error fetching commits from %s/%s: %v", user, repo.GetName(), err)
+						log.Fatalf("Error fetching contributed repos for user %s: %v", user, err)
 					}
-
-					repoHourlyCommits := processCommits(commits, userFilter)
-					for hour := 0; hour < 24; hour++ {
-						hourlyCommits[hour] += repoHourlyCommits[hour]
+					for _, repo := range contributedRepos {
+						targets = append(targets, targetRepo{Owner: repo.Owner, Repo: repo.Repository})
 					}
 				}
+
+				err = fetchReposConcurrently(ctx, client, targets, *concurrencyFlag, userFilter, loc, useCommitTZ, *bucketFlag, from, to, *noCacheFlag, *refreshFlag, &combined, perRepo)
+				if err != nil {
+					log.Fatalf("Error fetching commits for user %s: %v", user, err)
+				}
 			}
 		}
 	}
 
-	if err := generateGraph(hourlyCommits, outputFile, userFilter, repoFilter); err != nil {
+	renderer, err := pickRenderer(outputFile, *formatFlag)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	if *stackedFlag {
+		err = generateStackedGraph(perRepo, *bucketFlag, outputFile, userFilter, repoFilter, *tzFlag, renderer)
+	} else {
+		err = generateGraph(combined, outputFile, userFilter, repoFilter, *tzFlag, renderer)
+	}
+	if err != nil {
 		log.Fatalf("Error generating graph: %v", err)
 	}
 }
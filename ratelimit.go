@@ -0,0 +1,137 @@
+//
+//  Author: Hari Sekhon
+//  Date: 2024-10-20 09:17:44 +0100 (Sun, 20 Oct 2024)
+//
+//  vim:ts=4:sts=4:sw=4:et
+//
+//  https///github.com/HariSekhon/GitHub-Commit-Times-Graph
+//
+//  License: see accompanying Hari Sekhon LICENSE file
+//
+//  If you're using my code you're welcome to connect with me on LinkedIn and optionally send me feedback to help steer this or other code I publish
+//
+//  https://www.linkedin.com/in/HariSekhon
+//
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var errRateLimitRetriesExhausted = errors.New("exceeded retry attempts waiting out GitHub's rate limiting")
+
+// lowRateLimitThreshold is how many requests of headroom we insist on keeping before we start
+// proactively pausing ahead of the next request, rather than running the remaining quota dry
+const lowRateLimitThreshold = 50
+
+// rateLimitTransport inspects X-RateLimit-Remaining/X-RateLimit-Reset on every response and
+// sleeps ahead of the next request once remaining quota gets low, and retries secondary
+// rate-limit 403/429 responses honoring Retry-After (or an exponential backoff if absent).
+type rateLimitTransport struct {
+	transport http.RoundTripper
+
+	mu        sync.Mutex
+	remaining int
+	resetAt   time.Time
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.waitForQuota()
+
+	backoff := time.Second
+	for attempt := 0; attempt < 5; attempt++ {
+		resp, err := t.transport.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		t.recordLimits(resp)
+
+		if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+			rateLimited, err := isRateLimited(resp)
+			if err != nil {
+				return nil, err
+			}
+			if !rateLimited {
+				// a plain permission/auth problem (bad token scope, SSO not authorized, repo
+				// access denied, ...) - waiting won't fix it, so pass it through as-is
+				return resp, nil
+			}
+
+			wait := backoff
+			if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+				if secs, err := strconv.Atoi(retryAfter); err == nil {
+					wait = time.Duration(secs) * time.Second
+				}
+			}
+			resp.Body.Close()
+			log.Printf("Hit a secondary rate limit (status %d), backing off %s", resp.StatusCode, wait)
+			time.Sleep(wait)
+			backoff *= 2
+			continue
+		}
+
+		return resp, nil
+	}
+
+	return nil, errRateLimitRetriesExhausted
+}
+
+// isRateLimited distinguishes an actual (secondary) rate-limit response from a plain 403/429
+// permission or auth failure, by checking the primary-limit header, a Retry-After hint, or the
+// "rate limit" wording GitHub uses in the response body for secondary limits. It reads and
+// restores resp.Body so the caller can still use it unchanged either way.
+func isRateLimited(resp *http.Response) (bool, error) {
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		return true, nil
+	}
+	if resp.Header.Get("Retry-After") != "" {
+		return true, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return false, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	return bytes.Contains(bytes.ToLower(body), []byte("rate limit")), nil
+}
+
+func (t *rateLimitTransport) recordLimits(resp *http.Response) {
+	remaining, err1 := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	resetUnix, err2 := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err1 != nil || err2 != nil {
+		return
+	}
+
+	t.mu.Lock()
+	t.remaining = remaining
+	t.resetAt = time.Unix(resetUnix, 0)
+	t.mu.Unlock()
+}
+
+// waitForQuota sleeps until the primary rate limit resets if we're down to our last
+// lowRateLimitThreshold requests, rather than letting the pool's workers race it to zero
+func (t *rateLimitTransport) waitForQuota() {
+	t.mu.Lock()
+	remaining, resetAt := t.remaining, t.resetAt
+	t.mu.Unlock()
+
+	if remaining >= lowRateLimitThreshold || !time.Now().Before(resetAt) {
+		return
+	}
+
+	wait := time.Until(resetAt) + time.Second
+	log.Printf("Rate limit low (%d remaining); sleeping %s until reset", remaining, wait.Round(time.Second))
+	time.Sleep(wait)
+}
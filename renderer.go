@@ -0,0 +1,182 @@
+//
+//  Author: Hari Sekhon
+//  Date: 2024-11-03 16:28:55 +0000 (Sun, 03 Nov 2024)
+//
+//  vim:ts=4:sts=4:sw=4:et
+//
+//  https///github.com/HariSekhon/GitHub-Commit-Times-Graph
+//
+//  License: see accompanying Hari Sekhon LICENSE file
+//
+//  If you're using my code you're welcome to connect with me on LinkedIn and optionally send me feedback to help steer this or other code I publish
+//
+//  https://www.linkedin.com/in/HariSekhon
+//
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/vgimg"
+	"gonum.org/v1/plot/vg/vgsvg"
+)
+
+// RenderData carries the raw histogram values alongside the rendered plot so the HTML renderer
+// can embed them as a JSON data island for hover tooltips; the SVG and PNG renderers ignore it.
+type RenderData struct {
+	Labels  []string             `json:"labels"`
+	Values  []float64            `json:"values,omitempty"`
+	PerRepo map[string][]float64 `json:"per_repo,omitempty"`
+}
+
+// Renderer draws a finished plot.Plot onto a canvas of the given size and writes it to outputFile
+type Renderer interface {
+	Render(p *plot.Plot, width, height vg.Length, outputFile string, data RenderData) error
+}
+
+// pickRenderer chooses a Renderer from an explicit --format override, falling back to sniffing
+// outputFile's extension (.png, .html/.htm, otherwise .svg)
+func pickRenderer(outputFile, format string) (Renderer, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(outputFile)) {
+		case ".png":
+			format = "png"
+		case ".html", ".htm":
+			format = "html"
+		default:
+			format = "svg"
+		}
+	}
+
+	switch format {
+	case "svg":
+		return svgRenderer{}, nil
+	case "png":
+		return pngRenderer{}, nil
+	case "html":
+		return htmlRenderer{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported --format %q (expected svg, png or html)", format)
+	}
+}
+
+// svgRenderer writes the plot out as a standalone SVG file, the tool's original output format
+type svgRenderer struct{}
+
+func (svgRenderer) Render(p *plot.Plot, width, height vg.Length, outputFile string, _ RenderData) error {
+	canvas := vgsvg.New(width, height)
+	p.Draw(draw.New(canvas))
+
+	w, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	if _, err := canvas.WriteTo(w); err != nil {
+		return err
+	}
+
+	fmt.Printf("Graph saved to %s\n", outputFile)
+	return nil
+}
+
+// pngRenderer writes the plot out as a rasterized PNG file
+type pngRenderer struct{}
+
+func (pngRenderer) Render(p *plot.Plot, width, height vg.Length, outputFile string, _ RenderData) error {
+	canvas := vgimg.New(width, height)
+	p.Draw(draw.New(canvas))
+
+	w, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	png := vgimg.PngCanvas{Canvas: canvas}
+	if _, err := png.WriteTo(w); err != nil {
+		return err
+	}
+
+	fmt.Printf("Graph saved to %s\n", outputFile)
+	return nil
+}
+
+// htmlRenderer embeds the plot as an inline <svg> in a minimal HTML page, alongside a JSON data
+// island of the underlying values so a browser can wire up hover tooltips without a server
+type htmlRenderer struct{}
+
+func (htmlRenderer) Render(p *plot.Plot, width, height vg.Length, outputFile string, data RenderData) error {
+	canvas := vgsvg.New(width, height)
+	p.Draw(draw.New(canvas))
+
+	var svgBuf bytes.Buffer
+	if _, err := canvas.WriteTo(&svgBuf); err != nil {
+		return err
+	}
+
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	page := fmt.Sprintf(htmlTemplate, html.EscapeString(p.Title.Text), svgBuf.String(), dataJSON)
+
+	if err := os.WriteFile(outputFile, []byte(page), 0o644); err != nil {
+		return err
+	}
+
+	fmt.Printf("Graph saved to %s\n", outputFile)
+	return nil
+}
+
+// htmlTemplate wraps the rendered SVG with a JSON data island and a small hover handler that
+// shows a bucket label, and its count when the data island carries combined values, for the SVG
+// rect under the cursor in a line above the graph
+const htmlTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>body { font-family: sans-serif; } #tooltip { height: 1.5em; }</style>
+</head>
+<body>
+<div id="tooltip">&nbsp;</div>
+%s
+<script type="application/json" id="commit-data">%s</script>
+<script>
+(function() {
+	var data = JSON.parse(document.getElementById('commit-data').textContent);
+	var tooltip = document.getElementById('tooltip');
+	document.querySelectorAll('svg rect').forEach(function(rect, i) {
+		rect.addEventListener('mouseenter', function() {
+			var label = data.labels && data.labels[i %% data.labels.length];
+			if (label === undefined) {
+				return;
+			}
+			var text = label;
+			if (data.values) {
+				text += ': ' + data.values[i %% data.labels.length];
+			}
+			tooltip.textContent = text;
+		});
+		rect.addEventListener('mouseleave', function() {
+			tooltip.textContent = ' ';
+		});
+	});
+})();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,83 @@
+//
+//  Author: Hari Sekhon
+//  Date: 2024-10-20 09:42:18 +0100 (Sun, 20 Oct 2024)
+//
+//  vim:ts=4:sts=4:sw=4:et
+//
+//  https///github.com/HariSekhon/GitHub-Commit-Times-Graph
+//
+//  License: see accompanying Hari Sekhon LICENSE file
+//
+//  If you're using my code you're welcome to connect with me on LinkedIn and optionally send me feedback to help steer this or other code I publish
+//
+//  https://www.linkedin.com/in/HariSekhon
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v41/github"
+	"golang.org/x/sync/errgroup"
+)
+
+// fetchReposConcurrently fetches and aggregates commit histograms for repos using a worker pool
+// bounded to concurrency workers (a buffered-channel semaphore around an errgroup.Group), merging each repo's
+// histogram into combined under a mutex as it completes. When perRepo is non-nil, each repo's own
+// histogram is also recorded there (keyed by "owner/repo") for --stacked rendering.
+func fetchReposConcurrently(
+	ctx context.Context,
+	client *github.Client,
+	repos []targetRepo,
+	concurrency int,
+	usernameFilter string,
+	loc *time.Location,
+	useCommitTZ bool,
+	bucket string,
+	from, to time.Time,
+	noCache, refresh bool,
+	combined *CommitHistogram,
+	perRepo map[string]CommitHistogram,
+) error {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	sem := make(chan struct{}, concurrency)
+	var mu sync.Mutex
+
+	for _, repo := range repos {
+		repo := repo
+
+		select {
+		case sem <- struct{}{}:
+		case <-gctx.Done():
+			return g.Wait()
+		}
+
+		g.Go(func() error {
+			defer func() { <-sem }()
+
+			fmt.Printf("Fetching commits from %s/%s...\n", repo.Owner, repo.Repo)
+			hist, err := fetchRepoHistogram(client, repo.Owner, repo.Repo, usernameFilter, loc, useCommitTZ, bucket, from, to, noCache, refresh)
+			if err != nil {
+				return fmt.Errorf("fetching commits from %s/%s: %w", repo.Owner, repo.Repo, err)
+			}
+
+			mu.Lock()
+			mergeHistogram(combined, hist)
+			if perRepo != nil {
+				perRepo[repo.Owner+"/"+repo.Repo] = hist
+			}
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	return g.Wait()
+}